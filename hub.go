@@ -2,16 +2,32 @@ package hub
 
 import (
 	"context"
+	"sort"
 	"time"
+
+	"github.com/go-x-pkg/hub/service"
 )
 
 type Hub struct {
+	service.BaseService
+
 	pub   chan interface{}
 	sub   chan chan interface{}
 	unsub chan chan interface{}
 
-	done chan struct{}
-	stop chan struct{}
+	subTopic  chan topicRegistration
+	pubTopic  chan topicMsg
+	topicsReq chan chan []string
+
+	subOpts  chan subOptsRegistration
+	statsReq chan chan []SubStats
+	sinkReq  chan MetricsSink
+}
+
+// subOptsRegistration is sent over Hub.subOpts to register ch with opts.
+type subOptsRegistration struct {
+	ch   chan interface{}
+	opts SubOptions
 }
 
 func msgUnref(any interface{}) {
@@ -26,56 +42,96 @@ func msgRefDelta(any interface{}, delta int64) {
 	}
 }
 
-func (h *Hub) Stop() { h.stop <- struct{}{} }
-func (h *Hub) StopNonBlock() {
-	select {
-	case h.stop <- struct{}{}:
-	default:
+// Close stops the Hub and waits for its Start loop to exit. If ctx expires
+// first, Close returns ctx.Err() immediately. Close discards nothing itself:
+// the run loop's teardown Unrefs whatever is still buffered in subscription
+// channels regardless of ctx, and messages already queued inside a
+// Subscription are still passed to its callback until it is unsubscribed.
+func (h *Hub) Close(ctx context.Context) error {
+	if err := h.Stop(); err != nil && err != service.ErrAlreadyStopped {
+		return err
 	}
-}
 
-func (h *Hub) StopWithContext(ctx context.Context) {
 	select {
-	case h.stop <- struct{}{}:
+	case <-h.BaseService.Done():
+		return nil
 	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
-func (h *Hub) Done() { <-h.done }
-func (h *Hub) DoneWithContext(ctx context.Context) {
-	select {
-	case <-h.done:
-	case <-ctx.Done():
-	}
+
+// Start launches the Hub's run loop on its own goroutine, deriving a
+// cancellable context from ctx, and returns immediately. Calling Start
+// again while already running returns service.ErrAlreadyStarted.
+func (h *Hub) Start(ctx context.Context) error {
+	return h.BaseService.Start(ctx, h.run)
 }
 
-func (h *Hub) Start(ctx context.Context) {
-	if ctx == nil {
-		ctx = context.TODO()
-	}
+// run is the Hub's broadcast loop; it exits once ctx is done.
+func (h *Hub) run(ctx context.Context) {
+	// subStates holds per-channel policy and counters for every
+	// subscription, global or topic-only.
+	subStates := map[chan interface{}]*subState{}
 
-	defer func() {
-		select {
-		case h.done <- struct{}{}:
-		default:
-		}
-	}()
+	// topicSubs indexes subscriptions by the topic pattern they were
+	// registered with; subTopics is the reverse index used to clean up a
+	// channel (across all of its patterns) on unsub/close.
+	topicSubs := map[string]map[chan interface{}]struct{}{}
+	subTopics := map[chan interface{}][]string{}
+
+	var sink MetricsSink
 
-	subs := map[chan interface{}]struct{}{}
+	fnObserve := func(st *subState) {
+		if sink != nil {
+			sink.Observe(st.stats())
+		}
+	}
 
 	fnBroadcast := func(msg interface{}) {
-		if len(subs) == 0 {
+		globals := make([]*subState, 0, len(subStates))
+		for _, st := range subStates {
+			if st.global {
+				globals = append(globals, st)
+			}
+		}
+
+		if len(globals) == 0 {
 			msgUnref(msg)
-		} else {
-			msgRefDelta(msg, int64(len(subs)))
-
-			for sub := range subs {
-				select {
-				case sub <- msg:
-				default:
-					//! TODO: drop and requeu
-					msgUnref(msg)
-				}
+			return
+		}
+
+		msgRefDelta(msg, int64(len(globals)))
+
+		for _, st := range globals {
+			deliverToSub(st, msg)
+			fnObserve(st)
+		}
+	}
+
+	fnBroadcastTopic := func(topic string, msg interface{}) {
+		delivered := map[chan interface{}]struct{}{}
+
+		for pattern, chans := range topicSubs {
+			if !topicMatch(pattern, topic) {
+				continue
 			}
+
+			for sub := range chans {
+				delivered[sub] = struct{}{}
+			}
+		}
+
+		if len(delivered) == 0 {
+			msgUnref(msg)
+			return
+		}
+
+		msgRefDelta(msg, int64(len(delivered)))
+
+		for sub := range delivered {
+			st := subStates[sub]
+			deliverToSub(st, msg)
+			fnObserve(st)
 		}
 	}
 
@@ -91,31 +147,82 @@ func (h *Hub) Start(ctx context.Context) {
 		}
 	}
 
+	fnUnsubTopic := func(sub chan interface{}) {
+		for _, pattern := range subTopics[sub] {
+			delete(topicSubs[pattern], sub)
+
+			if len(topicSubs[pattern]) == 0 {
+				delete(topicSubs, pattern)
+			}
+		}
+
+		delete(subTopics, sub)
+	}
+
 	fnClose := func() {
-		for sub := range subs {
+		for sub := range subStates {
 			fnCloseSub(sub)
 		}
 	}
 
 	for {
 		select {
-		case <-h.stop:
-			fnClose()
-			return
-
 		case <-ctx.Done():
 			fnClose()
 			return
 
 		case sub := <-h.sub:
-			subs[sub] = struct{}{}
+			subStates[sub] = &subState{ch: sub, policy: PolicyDropNewest(), global: true}
+
+		case reg := <-h.subOpts:
+			policy := reg.opts.Policy
+			if policy == nil {
+				policy = PolicyDropNewest()
+			}
+
+			subStates[reg.ch] = &subState{ch: reg.ch, policy: policy, name: reg.opts.Name, global: true}
+
+		case reg := <-h.subTopic:
+			subTopics[reg.ch] = reg.topics
+			subStates[reg.ch] = &subState{ch: reg.ch, policy: PolicyDropNewest()}
+
+			for _, pattern := range reg.topics {
+				if topicSubs[pattern] == nil {
+					topicSubs[pattern] = map[chan interface{}]struct{}{}
+				}
+
+				topicSubs[pattern][reg.ch] = struct{}{}
+			}
 
 		case sub := <-h.unsub:
-			delete(subs, sub)
+			delete(subStates, sub)
+			fnUnsubTopic(sub)
 			fnCloseSub(sub)
 
 		case msg := <-h.pub:
 			fnBroadcast(msg)
+
+		case tm := <-h.pubTopic:
+			fnBroadcastTopic(tm.topic, tm.msg)
+
+		case resp := <-h.topicsReq:
+			topics := make([]string, 0, len(topicSubs))
+			for pattern := range topicSubs {
+				topics = append(topics, pattern)
+			}
+			sort.Strings(topics)
+
+			resp <- topics
+
+		case resp := <-h.statsReq:
+			stats := make([]SubStats, 0, len(subStates))
+			for _, st := range subStates {
+				stats = append(stats, st.stats())
+			}
+
+			resp <- stats
+
+		case sink = <-h.sinkReq:
 		}
 	}
 }
@@ -146,6 +253,35 @@ func (h *Hub) SubWithContext(ctx context.Context, capacity int) (sub chan interf
 	return sub
 }
 
+// SubWithOptions subscribes with an explicit capacity, OverflowPolicy and
+// name; see SubOptions. A nil Policy defaults to PolicyDropNewest().
+func (h *Hub) SubWithOptions(opts SubOptions) (sub chan interface{}) {
+	if opts.Capacity <= 0 {
+		sub = make(chan interface{})
+	} else {
+		sub = make(chan interface{}, opts.Capacity)
+	}
+
+	h.subOpts <- subOptsRegistration{ch: sub, opts: opts}
+
+	return sub
+}
+
+// Stats returns a point-in-time snapshot of every subscription's delivery
+// counters.
+func (h *Hub) Stats() []SubStats {
+	resp := make(chan []SubStats, 1)
+	h.statsReq <- resp
+
+	return <-resp
+}
+
+// SetMetricsSink installs sink to receive a SubStats snapshot after every
+// delivery attempt to any subscription. Pass nil to stop reporting.
+func (h *Hub) SetMetricsSink(sink MetricsSink) {
+	h.sinkReq <- sink
+}
+
 func (h *Hub) Unsub(sub chan interface{}) {
 	h.unsub <- sub
 }
@@ -194,8 +330,13 @@ func (h *Hub) Init() {
 	h.sub = make(chan chan interface{})
 	h.unsub = make(chan chan interface{})
 
-	h.stop = make(chan struct{}, 1)
-	h.done = make(chan struct{}, 1)
+	h.subTopic = make(chan topicRegistration)
+	h.pubTopic = make(chan topicMsg)
+	h.topicsReq = make(chan chan []string)
+
+	h.subOpts = make(chan subOptsRegistration)
+	h.statsReq = make(chan chan []SubStats)
+	h.sinkReq = make(chan MetricsSink)
 }
 
 func NewHub() *Hub {
@@ -2,10 +2,11 @@ package hub
 
 import (
 	"context"
-	"errors"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/go-x-pkg/hub/service"
 )
 
 const (
@@ -87,7 +88,9 @@ func TestHub(t *testing.T) {
 			ctx, cancel := context.WithCancel(context.Background())
 			defer cancel()
 
-			go hub.Start(ctx)
+			if err := hub.Start(ctx); err != nil {
+				t.Fatalf("hub start failed: %v", err)
+			}
 
 			wgStart := sync.WaitGroup{}
 			wgStart.Add(subsCount)
@@ -128,7 +131,9 @@ func TestHub(t *testing.T) {
 				t.Fatalf("not all workers are done in time")
 			}
 
-			hub.Stop()
+			if err := hub.Stop(); err != nil {
+				t.Errorf("hub stop failed: %v", err)
+			}
 
 			if msg, ok := tt.msg.(*mockMsg); ok {
 				if msg.refDelta != int64(subsCount) {
@@ -136,11 +141,9 @@ func TestHub(t *testing.T) {
 				}
 			}
 
-			doneCtx, cancelDoneCtx := context.WithTimeout(ctx, timeout)
-			defer cancelDoneCtx()
-			hub.DoneWithContext(doneCtx)
-
-			if err := doneCtx.Err(); err != nil && errors.Is(err, context.DeadlineExceeded) {
+			select {
+			case <-hub.Done():
+			case <-time.After(timeout):
 				t.Errorf("hub doesn't done in time")
 			}
 		}()
@@ -152,49 +155,73 @@ func TestStopDone(t *testing.T) {
 	defer cancel()
 
 	hub := NewHub()
-	go hub.Start(ctx)
+	if err := hub.Start(ctx); err != nil {
+		t.Fatalf("hub start failed: %v", err)
+	}
 
 	_ = hub.Sub(-1)
 	_ = hub.Sub(0)
 	_ = hub.Sub(1)
 
-	hub.StopNonBlock()
-	hub.StopNonBlock()
+	if !hub.IsRunning() {
+		t.Errorf("hub should report running after Start")
+	}
 
-	stopCtx, cancelStopCtx := context.WithTimeout(ctx, timeout)
-	defer cancelStopCtx()
-	hub.StopWithContext(stopCtx)
+	if err := hub.Stop(); err != nil {
+		t.Errorf("hub stop failed: %v", err)
+	}
 
-	if err := stopCtx.Err(); err == nil {
-		t.Errorf("hub stop doesn't care about context")
+	if err := hub.Stop(); err != service.ErrAlreadyStopped {
+		t.Errorf("second hub.Stop() = %v, want %v", err, service.ErrAlreadyStopped)
 	}
 
 	doneWg := sync.WaitGroup{}
 	doneWg.Add(1)
 	go func() {
 		defer doneWg.Done()
-		hub.Done()
+		hub.Wait()
 	}()
 
 	if wgWaitTimeout(&doneWg, timeout) {
 		t.Fatalf("hub done failed")
 	}
 
-	doneCtx, cancelDoneCtx := context.WithTimeout(ctx, timeout)
-	defer cancelDoneCtx()
-	hub.DoneWithContext(doneCtx)
+	if hub.IsRunning() {
+		t.Errorf("hub should not report running after Stop")
+	}
+}
+
+func TestStopBeforeStart(t *testing.T) {
+	hub := NewHub()
+
+	if err := hub.Stop(); err != service.ErrNotStarted {
+		t.Errorf("hub.Stop() before Start = %v, want %v", err, service.ErrNotStarted)
+	}
+}
+
+func TestStartTwice(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	if err := hub.Start(ctx); err != nil {
+		t.Fatalf("hub start failed: %v", err)
+	}
+	defer hub.Stop()
 
-	if err := doneCtx.Err(); err == nil {
-		t.Errorf("hub done doesn't care about context")
+	if err := hub.Start(ctx); err != service.ErrAlreadyStarted {
+		t.Errorf("second hub.Start() = %v, want %v", err, service.ErrAlreadyStarted)
 	}
 }
 
 func TestStartNilCtx(t *testing.T) {
 	hub := NewHub()
-	go hub.Start(nil)
+	if err := hub.Start(nil); err != nil {
+		t.Fatalf("hub start failed: %v", err)
+	}
 
-	hub.StopNonBlock()
-	hub.Done()
+	hub.Stop()
+	hub.Wait()
 }
 
 // test hub can be canceled via ctx
@@ -202,7 +229,9 @@ func TestStartWithCtx(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	hub := NewHub()
-	go hub.Start(ctx)
+	if err := hub.Start(ctx); err != nil {
+		t.Fatalf("hub start failed: %v", err)
+	}
 
 	// cancel hub via ctx
 	cancel()
@@ -211,10 +240,31 @@ func TestStartWithCtx(t *testing.T) {
 	doneWg.Add(1)
 	go func() {
 		defer doneWg.Done()
-		hub.Done()
+		hub.Wait()
 	}()
 
 	if wgWaitTimeout(&doneWg, timeout) {
 		t.Fatalf("hub done failed")
 	}
 }
+
+func TestClose(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	if err := hub.Start(ctx); err != nil {
+		t.Fatalf("hub start failed: %v", err)
+	}
+
+	closeCtx, cancelCloseCtx := context.WithTimeout(ctx, timeout)
+	defer cancelCloseCtx()
+
+	if err := hub.Close(closeCtx); err != nil {
+		t.Errorf("hub close failed: %v", err)
+	}
+
+	if hub.IsRunning() {
+		t.Errorf("hub should not report running after Close")
+	}
+}
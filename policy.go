@@ -0,0 +1,196 @@
+package hub
+
+import "time"
+
+// OverflowPolicy decides what happens to a message when a subscription's
+// channel is full at broadcast time. Construct one with PolicyDropNewest,
+// PolicyDropOldest, PolicyBlockWithTimeout or PolicyCoalesce.
+type OverflowPolicy interface {
+	overflowPolicy()
+}
+
+type policyDropNewest struct{}
+
+func (policyDropNewest) overflowPolicy() {}
+
+type policyDropOldest struct{}
+
+func (policyDropOldest) overflowPolicy() {}
+
+type policyBlockWithTimeout struct{ timeout time.Duration }
+
+func (policyBlockWithTimeout) overflowPolicy() {}
+
+type policyCoalesce struct {
+	fn func(oldMsg, newMsg interface{}) interface{}
+}
+
+func (policyCoalesce) overflowPolicy() {}
+
+// PolicyDropNewest drops the incoming message when the subscription's
+// channel is full, leaving whatever is already queued untouched. This is
+// the default, and matches the Hub's original fire-and-forget behavior.
+func PolicyDropNewest() OverflowPolicy { return policyDropNewest{} }
+
+// PolicyDropOldest drops the oldest queued message to make room for the
+// incoming one when the subscription's channel is full.
+func PolicyDropOldest() OverflowPolicy { return policyDropOldest{} }
+
+// PolicyBlockWithTimeout blocks the broadcasting call for up to d waiting
+// for room in the subscription's channel, dropping the message if none
+// opens up in time. Since broadcasting happens on the Hub's single run
+// loop goroutine, a blocked subscriber delays delivery to every other
+// subscriber for up to d.
+func PolicyBlockWithTimeout(d time.Duration) OverflowPolicy {
+	return policyBlockWithTimeout{timeout: d}
+}
+
+// PolicyCoalesce merges the incoming message with the oldest queued one via
+// fn when the subscription's channel is full: the oldest is taken off the
+// channel and the merged result is sent to the back of it. fn is called
+// with (old, new) and must return the message to keep.
+func PolicyCoalesce(fn func(oldMsg, newMsg interface{}) interface{}) OverflowPolicy {
+	return policyCoalesce{fn: fn}
+}
+
+// SubOptions configures a subscription created via Hub.SubWithOptions.
+type SubOptions struct {
+	Capacity int
+	Policy   OverflowPolicy // nil defaults to PolicyDropNewest()
+	Name     string         // for Stats()/MetricsSink; need not be unique
+}
+
+// SubStats is a point-in-time snapshot of a subscription's delivery
+// counters, as returned by Hub.Stats() or pushed to a MetricsSink.
+type SubStats struct {
+	Name          string
+	Capacity      int
+	Delivered     uint64
+	Dropped       uint64
+	Coalesced     uint64
+	HighWatermark int
+}
+
+// MetricsSink receives a SubStats snapshot after every delivery attempt, so
+// callers can bridge Hub's internal counters to something like Prometheus.
+type MetricsSink interface {
+	Observe(stats SubStats)
+}
+
+// subState is the Hub run loop's bookkeeping for a single subscription
+// channel: its overflow policy and its delivery counters. It is only ever
+// touched from the run loop goroutine.
+type subState struct {
+	ch     chan interface{}
+	policy OverflowPolicy
+	name   string
+	global bool // receives plain Pub broadcasts, as opposed to topic-only
+
+	delivered     uint64
+	dropped       uint64
+	coalesced     uint64
+	highWatermark int
+}
+
+func (st *subState) stats() SubStats {
+	return SubStats{
+		Name:          st.name,
+		Capacity:      cap(st.ch),
+		Delivered:     st.delivered,
+		Dropped:       st.dropped,
+		Coalesced:     st.coalesced,
+		HighWatermark: st.highWatermark,
+	}
+}
+
+func (st *subState) recordWatermark() {
+	if n := len(st.ch); n > st.highWatermark {
+		st.highWatermark = n
+	}
+}
+
+// deliverToSub attempts to hand msg to st's channel, applying st's overflow
+// policy if it's full, and updates st's counters accordingly. msg is
+// always either delivered or Unrefed exactly once.
+func deliverToSub(st *subState, msg interface{}) {
+	select {
+	case st.ch <- msg:
+		st.delivered++
+		st.recordWatermark()
+
+		return
+	default:
+	}
+
+	switch p := st.policy.(type) {
+	case policyDropOldest:
+		select {
+		case old := <-st.ch:
+			msgUnref(old)
+			st.dropped++
+		default:
+		}
+
+		select {
+		case st.ch <- msg:
+			st.delivered++
+		default:
+			// lost the freed slot to a concurrent receive
+			st.dropped++
+			msgUnref(msg)
+		}
+
+	case policyBlockWithTimeout:
+		timer := time.NewTimer(p.timeout)
+
+		select {
+		case st.ch <- msg:
+			st.delivered++
+
+			if !timer.Stop() {
+				<-timer.C
+			}
+		case <-timer.C:
+			st.dropped++
+			msgUnref(msg)
+		}
+
+	case policyCoalesce:
+		select {
+		case old := <-st.ch:
+			merged := p.fn(old, msg)
+
+			// old and msg each already carry a ref from their own
+			// broadcast; merged replaces both as the one pending
+			// delivery for this subscriber, so it needs a ref of its
+			// own instead of inheriting either one's.
+			msgUnref(old)
+			msgUnref(msg)
+			msgRefDelta(merged, 1)
+
+			select {
+			case st.ch <- merged:
+				st.coalesced++
+			default:
+				st.dropped++
+				msgUnref(merged)
+			}
+		default:
+			// buffer was drained by a concurrent receive; nothing to
+			// coalesce with, fall back to a plain (best-effort) send
+			select {
+			case st.ch <- msg:
+				st.delivered++
+			default:
+				st.dropped++
+				msgUnref(msg)
+			}
+		}
+
+	default: // policyDropNewest and anything unrecognized
+		st.dropped++
+		msgUnref(msg)
+	}
+
+	st.recordWatermark()
+}
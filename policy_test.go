@@ -0,0 +1,197 @@
+package hub
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func startTestHub(t *testing.T) (*Hub, func()) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	h := NewHub()
+	if err := h.Start(ctx); err != nil {
+		t.Fatalf("hub start failed: %v", err)
+	}
+
+	return h, func() {
+		h.Stop()
+		cancel()
+	}
+}
+
+func TestSubWithOptionsDropOldest(t *testing.T) {
+	h, stop := startTestHub(t)
+	defer stop()
+
+	sub := h.SubWithOptions(SubOptions{Capacity: 2, Policy: PolicyDropOldest(), Name: "drop-oldest"})
+
+	h.Pub(1)
+	h.Pub(2)
+	h.Pub(3) // channel full (1, 2); should evict 1 and keep 2, 3
+
+	time.Sleep(10 * time.Millisecond) // let the run loop process all three
+
+	got := []int{(<-sub).(int), (<-sub).(int)}
+	if got[0] != 2 || got[1] != 3 {
+		t.Errorf("got %v, want [2 3]", got)
+	}
+
+	stats := h.Stats()
+	if len(stats) != 1 || stats[0].Dropped != 1 || stats[0].Name != "drop-oldest" {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSubWithOptionsCoalesce(t *testing.T) {
+	h, stop := startTestHub(t)
+	defer stop()
+
+	coalesceFn := func(oldMsg, newMsg interface{}) interface{} {
+		return oldMsg.(int) + newMsg.(int)
+	}
+
+	sub := h.SubWithOptions(SubOptions{Capacity: 1, Policy: PolicyCoalesce(coalesceFn)})
+
+	h.Pub(1)
+	h.Pub(2) // channel full (1); should coalesce into 1+2=3
+
+	time.Sleep(10 * time.Millisecond)
+
+	if got := (<-sub).(int); got != 3 {
+		t.Errorf("got %v, want 3", got)
+	}
+
+	stats := h.Stats()
+	if len(stats) != 1 || stats[0].Coalesced != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestSubWithOptionsCoalesceRefCounting(t *testing.T) {
+	h, stop := startTestHub(t)
+	defer stop()
+
+	merged := &mockMsg{}
+
+	coalesceFn := func(oldMsg, newMsg interface{}) interface{} {
+		return merged
+	}
+
+	sub := h.SubWithOptions(SubOptions{Capacity: 1, Policy: PolicyCoalesce(coalesceFn)})
+
+	m1 := &mockMsg{}
+	m2 := &mockMsg{}
+
+	h.Pub(m1)
+
+	time.Sleep(10 * time.Millisecond) // let the run loop land m1 before m2 arrives
+
+	h.Pub(m2) // channel full (m1); should coalesce m1+m2 into merged
+
+	time.Sleep(10 * time.Millisecond)
+
+	got := (<-sub).(*mockMsg)
+	got.Unref() // simulate the consumer releasing its ref once done
+
+	if got != merged {
+		t.Fatalf("got %v, want the coalesced object %v", got, merged)
+	}
+
+	if m1.refDelta != 1 || m1.unrefHit != 1 {
+		t.Errorf("m1: refDelta=%d unrefHit=%d, want 1/1 (fully accounted)", m1.refDelta, m1.unrefHit)
+	}
+
+	if m2.refDelta != 1 || m2.unrefHit != 1 {
+		t.Errorf("m2: refDelta=%d unrefHit=%d, want 1/1 (fully accounted)", m2.refDelta, m2.unrefHit)
+	}
+
+	if merged.refDelta != 1 || merged.unrefHit != 1 {
+		t.Errorf("merged: refDelta=%d unrefHit=%d, want 1/1 (fully accounted)", merged.refDelta, merged.unrefHit)
+	}
+}
+
+func TestSubWithOptionsBlockWithTimeout(t *testing.T) {
+	h, stop := startTestHub(t)
+	defer stop()
+
+	sub := h.SubWithOptions(SubOptions{Capacity: 1, Policy: PolicyBlockWithTimeout(20 * time.Millisecond)})
+
+	h.Pub(1) // fills the buffer
+	h.Pub(2) // nobody drains sub, so this blocks the run loop for ~20ms then drops
+
+	deadline := time.Now().Add(timeout)
+	var stats []SubStats
+	for time.Now().Before(deadline) {
+		stats = h.Stats()
+		if len(stats) == 1 && stats[0].Dropped == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if len(stats) != 1 || stats[0].Dropped != 1 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+
+	if got := (<-sub).(int); got != 1 {
+		t.Errorf("got %v, want 1", got)
+	}
+}
+
+// recordingSink is read from the test goroutine and written from the Hub's
+// run loop goroutine (via Observe), so snapshots needs its own lock.
+type recordingSink struct {
+	mu        sync.Mutex
+	snapshots []SubStats
+}
+
+func (s *recordingSink) Observe(stats SubStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.snapshots = append(s.snapshots, stats)
+}
+
+func (s *recordingSink) len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.snapshots)
+}
+
+func (s *recordingSink) first() SubStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.snapshots[0]
+}
+
+func TestMetricsSink(t *testing.T) {
+	h, stop := startTestHub(t)
+	defer stop()
+
+	sink := &recordingSink{}
+	h.SetMetricsSink(sink)
+
+	sub := h.SubWithOptions(SubOptions{Capacity: 1, Name: "metered"})
+	defer h.Unsub(sub)
+
+	h.Pub("hello")
+
+	deadline := time.Now().Add(timeout)
+	for sink.len() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+
+	if sink.len() == 0 {
+		t.Fatal("metrics sink received no observations")
+	}
+
+	if got := sink.first(); got.Delivered != 1 {
+		t.Errorf("snapshot = %+v, want Delivered = 1", got)
+	}
+}
@@ -0,0 +1,146 @@
+// Package service provides a small embeddable helper for types with a
+// Start(ctx)/Stop()/Wait() lifecycle, so they don't each have to reinvent
+// idempotent start/stop bookkeeping around a context.
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrAlreadyStarted is returned by Start when the service is already
+	// running.
+	ErrAlreadyStarted = errors.New("service: already started")
+
+	// ErrAlreadyStopped is returned by Stop when the service has already
+	// been stopped, or its run func has already returned on its own.
+	ErrAlreadyStopped = errors.New("service: already stopped")
+
+	// ErrNotStarted is returned by Stop when the service was never started.
+	ErrNotStarted = errors.New("service: not started")
+)
+
+// Service is the standard lifecycle implemented by types embedding
+// BaseService.
+type Service interface {
+	Start(ctx context.Context) error
+	Stop() error
+	Wait()
+	IsRunning() bool
+}
+
+// BaseService implements the bookkeeping behind Service: idempotent
+// Start/Stop, a Wait that blocks until the run loop has returned, and a
+// Done channel for select-based waiting. It does not run anything itself —
+// embedders pass their own run func to Start.
+type BaseService struct {
+	mu      sync.Mutex
+	started bool
+	running bool
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+// Start launches run on its own goroutine with a context derived from ctx,
+// and returns immediately. run must return (promptly) once the derived
+// context is done. Calling Start while already running returns
+// ErrAlreadyStarted.
+func (b *BaseService) Start(ctx context.Context, run func(ctx context.Context)) error {
+	b.mu.Lock()
+
+	if b.running {
+		b.mu.Unlock()
+		return ErrAlreadyStarted
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	runCtx, cancel := context.WithCancel(ctx)
+
+	b.cancel = cancel
+	b.done = make(chan struct{})
+	b.started = true
+	b.running = true
+
+	done := b.done
+	b.mu.Unlock()
+
+	go func() {
+		defer func() {
+			b.mu.Lock()
+			b.running = false
+			b.mu.Unlock()
+
+			close(done)
+		}()
+
+		run(runCtx)
+	}()
+
+	return nil
+}
+
+// Stop cancels the context passed to run and returns immediately; it does
+// not wait for run to actually exit, use Wait or Done for that. Stop is
+// idempotent: calling it before Start returns ErrNotStarted, calling it
+// again after a prior Stop (or after run has already returned on its own)
+// returns ErrAlreadyStopped.
+func (b *BaseService) Stop() error {
+	b.mu.Lock()
+
+	if !b.started {
+		b.mu.Unlock()
+		return ErrNotStarted
+	}
+
+	if !b.running {
+		b.mu.Unlock()
+		return ErrAlreadyStopped
+	}
+
+	b.running = false
+	cancel := b.cancel
+
+	b.mu.Unlock()
+
+	cancel()
+
+	return nil
+}
+
+// Wait blocks until run has returned. It returns immediately if Start was
+// never called.
+func (b *BaseService) Wait() {
+	b.mu.Lock()
+	done := b.done
+	b.mu.Unlock()
+
+	if done == nil {
+		return
+	}
+
+	<-done
+}
+
+// Done returns a channel that's closed once run has returned. It returns
+// nil if Start was never called.
+func (b *BaseService) Done() <-chan struct{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.done
+}
+
+// IsRunning reports whether the service has been started and not yet
+// stopped, either by Stop or by run returning. It reports false as soon as
+// Stop is called, which may be before run has actually returned.
+func (b *BaseService) IsRunning() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.running
+}
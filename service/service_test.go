@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+const timeout = 100 * time.Millisecond
+
+func TestBaseServiceLifecycle(t *testing.T) {
+	var b BaseService
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ran := make(chan struct{})
+
+	run := func(ctx context.Context) {
+		close(ran)
+		<-ctx.Done()
+	}
+
+	if err := b.Start(ctx, run); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	select {
+	case <-ran:
+	case <-time.After(timeout):
+		t.Fatal("run was never called")
+	}
+
+	if !b.IsRunning() {
+		t.Error("IsRunning() = false, want true")
+	}
+
+	if err := b.Start(ctx, run); err != ErrAlreadyStarted {
+		t.Errorf("second Start() = %v, want %v", err, ErrAlreadyStarted)
+	}
+
+	if err := b.Stop(); err != nil {
+		t.Errorf("stop failed: %v", err)
+	}
+
+	b.Wait()
+
+	if b.IsRunning() {
+		t.Error("IsRunning() = true after Stop+Wait, want false")
+	}
+
+	if err := b.Stop(); err != ErrAlreadyStopped {
+		t.Errorf("second Stop() = %v, want %v", err, ErrAlreadyStopped)
+	}
+
+	select {
+	case <-b.Done():
+	default:
+		t.Error("Done() channel should be closed after Wait returns")
+	}
+}
+
+func TestBaseServiceStopBeforeStart(t *testing.T) {
+	var b BaseService
+
+	if err := b.Stop(); err != ErrNotStarted {
+		t.Errorf("Stop() before Start = %v, want %v", err, ErrNotStarted)
+	}
+
+	if b.IsRunning() {
+		t.Error("IsRunning() = true before Start, want false")
+	}
+
+	b.Wait() // must not block
+}
+
+func TestBaseServiceRunExitsOnItsOwn(t *testing.T) {
+	var b BaseService
+
+	if err := b.Start(context.Background(), func(ctx context.Context) {}); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+
+	b.Wait()
+
+	if b.IsRunning() {
+		t.Error("IsRunning() = true after run returned on its own, want false")
+	}
+
+	if err := b.Stop(); err != ErrAlreadyStopped {
+		t.Errorf("Stop() after run exited on its own = %v, want %v", err, ErrAlreadyStopped)
+	}
+}
@@ -0,0 +1,165 @@
+package hub
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Partitioner picks which shard a message should be published on. The
+// returned value is taken mod the ShardedHub's shard count, so the full
+// range of uint64 is fine to return.
+type Partitioner func(msg interface{}) uint64
+
+// ShardedHub fans Pub out across N independent Hub goroutines, so
+// broadcasting to many subscribers isn't serialized through a single run
+// loop. Use it once a single Hub's broadcast loop is the bottleneck, e.g.
+// thousands of subscribers.
+type ShardedHub struct {
+	shards      []*Hub
+	partitioner Partitioner
+	rr          uint64 // round-robin counter for the default partitioner
+}
+
+// NewShardedHub creates a ShardedHub with n shards (at least 1). A nil
+// partitioner defaults to round-robin.
+func NewShardedHub(n int, partitioner Partitioner) *ShardedHub {
+	if n <= 0 {
+		n = 1
+	}
+
+	sh := &ShardedHub{
+		shards:      make([]*Hub, n),
+		partitioner: partitioner,
+	}
+
+	for i := range sh.shards {
+		sh.shards[i] = NewHub()
+	}
+
+	if sh.partitioner == nil {
+		sh.partitioner = sh.roundRobin
+	}
+
+	return sh
+}
+
+func (sh *ShardedHub) roundRobin(interface{}) uint64 {
+	return atomic.AddUint64(&sh.rr, 1)
+}
+
+// Shards returns the number of underlying Hub shards.
+func (sh *ShardedHub) Shards() int { return len(sh.shards) }
+
+// Shard returns the i'th underlying Hub, for pinning a subscription (or
+// publishing directly) to a specific shard. i is taken mod Shards().
+func (sh *ShardedHub) Shard(i int) *Hub {
+	return sh.shards[i%len(sh.shards)]
+}
+
+// Start starts every shard. If any shard fails to start, the ones already
+// started are stopped before Start returns the error.
+func (sh *ShardedHub) Start(ctx context.Context) error {
+	for i, h := range sh.shards {
+		if err := h.Start(ctx); err != nil {
+			for _, started := range sh.shards[:i] {
+				started.Stop()
+			}
+
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Stop stops every shard, returning the first error encountered (if any)
+// after attempting to stop all of them.
+func (sh *ShardedHub) Stop() error {
+	var firstErr error
+
+	for _, h := range sh.shards {
+		if err := h.Stop(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// Wait blocks until every shard's run loop has returned.
+func (sh *ShardedHub) Wait() {
+	for _, h := range sh.shards {
+		h.Wait()
+	}
+}
+
+func (sh *ShardedHub) shardFor(msg interface{}) *Hub {
+	idx := sh.partitioner(msg) % uint64(len(sh.shards))
+	return sh.shards[idx]
+}
+
+// Pub publishes msg on the shard picked by the ShardedHub's Partitioner.
+// Ref-counting is unaffected by sharding: msg is still published to, and
+// accounted for by, exactly one Hub's run loop.
+func (sh *ShardedHub) Pub(msg interface{}) {
+	sh.shardFor(msg).Pub(msg)
+}
+
+// Sub subscribes to a single shard, pinned by index (mod Shards()).
+func (sh *ShardedHub) Sub(shard, capacity int) chan interface{} {
+	return sh.Shard(shard).Sub(capacity)
+}
+
+// SubAll subscribes across every shard, merging deliveries onto a single
+// channel of the given capacity. Call the returned cancel func to
+// unsubscribe from every shard and stop the merging goroutines; messages
+// that arrive after cancellation are Unrefed rather than delivered.
+func (sh *ShardedHub) SubAll(capacity int) (merged chan interface{}, cancel func()) {
+	merged = make(chan interface{}, capacity)
+
+	subs := make([]chan interface{}, len(sh.shards))
+	for i, h := range sh.shards {
+		subs[i] = h.Sub(capacity)
+	}
+
+	done := make(chan struct{})
+
+	for i, h := range sh.shards {
+		go func(h *Hub, sub chan interface{}) {
+			for {
+				select {
+				case msg, ok := <-sub:
+					if !ok {
+						return
+					}
+
+					select {
+					case merged <- msg:
+					default:
+						msgUnref(msg)
+					}
+
+				case <-done:
+					// A plain Unsub blocks on the run loop accepting the
+					// send; race it against the run loop's own exit so a
+					// concurrent Stop (e.g. from the owning ShardedHub)
+					// can't leave this goroutine blocked and sub's
+					// subState registered forever.
+					select {
+					case h.unsub <- sub:
+					case <-h.BaseService.Done():
+					}
+					return
+				}
+			}
+		}(h, subs[i])
+	}
+
+	var once sync.Once
+	cancel = func() {
+		once.Do(func() { close(done) })
+	}
+
+	return merged, cancel
+}
@@ -0,0 +1,168 @@
+package hub
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestShardedHubPub(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sh := NewShardedHub(4, nil)
+	if err := sh.Start(ctx); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	defer sh.Stop()
+
+	merged, cancelAll := sh.SubAll(100)
+	defer cancelAll()
+
+	const n = 40
+
+	for i := 0; i < n; i++ {
+		sh.Pub(i)
+	}
+
+	got := map[int]bool{}
+	deadline := time.After(timeout)
+
+	for len(got) < n {
+		select {
+		case msg := <-merged:
+			got[msg.(int)] = true
+		case <-deadline:
+			t.Fatalf("only received %d/%d messages in time", len(got), n)
+		}
+	}
+}
+
+func TestShardedHubPinnedShard(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sh := NewShardedHub(4, func(msg interface{}) uint64 { return uint64(msg.(int)) })
+	if err := sh.Start(ctx); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	defer sh.Stop()
+
+	sub := sh.Sub(1, 1)
+
+	sh.Pub(1) // partitions to shard 1 % 4 == 1
+
+	select {
+	case msg := <-sub:
+		if msg.(int) != 1 {
+			t.Errorf("got %v, want 1", msg)
+		}
+	case <-time.After(timeout):
+		t.Fatal("pinned shard subscriber never received the message")
+	}
+}
+
+func TestShardedHubRefCounting(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sh := NewShardedHub(4, nil)
+	if err := sh.Start(ctx); err != nil {
+		t.Fatalf("start failed: %v", err)
+	}
+	defer sh.Stop()
+
+	merged, cancelAll := sh.SubAll(10)
+	defer cancelAll()
+
+	msg := &mockMsg{}
+	sh.Pub(msg)
+
+	select {
+	case <-merged:
+	case <-time.After(timeout):
+		t.Fatal("message never arrived")
+	}
+
+	// exactly one shard should have delivered it, and so counted exactly
+	// one ref-delta for it.
+	if msg.refDelta != 1 {
+		t.Errorf("refDelta = %d, want 1", msg.refDelta)
+	}
+}
+
+// BenchmarkHubPub1kSubs and friends compare broadcasting through a single
+// Hub against a ShardedHub at increasing subscriber counts.
+func benchmarkHubPub(b *testing.B, subsCount int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	h := NewHub()
+	if err := h.Start(ctx); err != nil {
+		b.Fatalf("start failed: %v", err)
+	}
+	defer h.Stop()
+
+	for i := 0; i < subsCount; i++ {
+		sub := h.Sub(1)
+
+		go func(sub chan interface{}) {
+			for range sub {
+			}
+		}(sub)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		h.Pub(i)
+	}
+}
+
+func benchmarkShardedHubPub(b *testing.B, shards, subsCount int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sh := NewShardedHub(shards, nil)
+	if err := sh.Start(ctx); err != nil {
+		b.Fatalf("start failed: %v", err)
+	}
+	defer sh.Stop()
+
+	for i := 0; i < subsCount; i++ {
+		sub := sh.Sub(i, 1)
+
+		go func(sub chan interface{}) {
+			for range sub {
+			}
+		}(sub)
+	}
+
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		sh.Pub(i)
+	}
+}
+
+func BenchmarkHubPub1kSubs(b *testing.B)         { benchmarkHubPub(b, 1000) }
+func BenchmarkHubPub10kSubs(b *testing.B)        { benchmarkHubPub(b, 10000) }
+func BenchmarkShardedHubPub1kSubs(b *testing.B)  { benchmarkShardedHubPub(b, 8, 1000) }
+func BenchmarkShardedHubPub10kSubs(b *testing.B) { benchmarkShardedHubPub(b, 8, 10000) }
+
+func ExampleShardedHub() {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sh := NewShardedHub(4, nil)
+	_ = sh.Start(ctx)
+	defer sh.Stop()
+
+	merged, cancelAll := sh.SubAll(1)
+	defer cancelAll()
+
+	sh.Pub("hello")
+	fmt.Println(<-merged)
+	// Output: hello
+}
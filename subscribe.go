@@ -0,0 +1,160 @@
+package hub
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// Subscription is a callback-style subscription created via Subscribe or
+// SubscribeWithContext. Unlike a raw Sub channel, delivery to the callback
+// happens on a dedicated goroutine backed by an unbounded queue, so a slow
+// callback only ever delays its own subscription and never the Hub's Start
+// loop or any other subscriber.
+type Subscription struct {
+	hub *Hub
+	ch  chan interface{}
+	cb  func(msg interface{})
+
+	mu     sync.Mutex
+	cond   *sync.Cond
+	queue  *list.List
+	closed bool
+	drop   bool
+
+	unsubOnce sync.Once
+	delivery  chan struct{}
+}
+
+func newSubscription(h *Hub, ch chan interface{}, cb func(msg interface{})) *Subscription {
+	s := &Subscription{
+		hub:      h,
+		ch:       ch,
+		cb:       cb,
+		queue:    list.New(),
+		delivery: make(chan struct{}),
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	go s.feed()
+	go s.deliver()
+
+	return s
+}
+
+// feed reads off the Hub channel and appends to the unbounded queue, always
+// ready to receive so the Hub's broadcast select never blocks on a slow
+// callback.
+func (s *Subscription) feed() {
+	for msg := range s.ch {
+		s.mu.Lock()
+		s.queue.PushBack(msg)
+		s.cond.Signal()
+		s.mu.Unlock()
+	}
+
+	s.mu.Lock()
+	s.closed = true
+	s.cond.Signal()
+	s.mu.Unlock()
+}
+
+// deliver pops messages off the queue in FIFO order and runs the callback,
+// exiting once the queue is drained and closed.
+func (s *Subscription) deliver() {
+	defer close(s.delivery)
+
+	for {
+		s.mu.Lock()
+		for s.queue.Len() == 0 && !s.closed {
+			s.cond.Wait()
+		}
+
+		if s.queue.Len() == 0 && s.closed {
+			s.mu.Unlock()
+			return
+		}
+
+		msg := s.queue.Remove(s.queue.Front())
+		drop := s.drop
+		s.mu.Unlock()
+
+		if drop {
+			msgUnref(msg)
+			continue
+		}
+
+		s.cb(msg)
+		msgUnref(msg)
+	}
+}
+
+// unsubscribe tells the Hub to stop delivering to s.ch, drops any messages
+// still queued instead of running the callback for them, and waits for the
+// delivery goroutine to finish. It is safe to call more than once.
+func (s *Subscription) unsubscribe() {
+	s.unsubOnce.Do(func() {
+		// A plain Unsub blocks on the run loop accepting the send; race it
+		// against the run loop's own exit so a concurrent Stop/Close can't
+		// wedge this call forever. Either way s.ch ends up closed: Unsub
+		// has the run loop close it directly, and a run loop that's
+		// already exiting closes every remaining sub as part of teardown.
+		select {
+		case s.hub.unsub <- s.ch:
+		case <-s.hub.BaseService.Done():
+		}
+
+		s.mu.Lock()
+		s.drop = true
+		s.mu.Unlock()
+		s.cond.Signal()
+	})
+
+	<-s.delivery
+}
+
+// Unsubscribe removes the subscription from the Hub, then drains and Unrefs
+// any messages still sitting in the queue before returning.
+func (s *Subscription) Unsubscribe() {
+	s.unsubscribe()
+}
+
+// subscribeBridgeCapacity buffers the channel Subscribe registers with the
+// Hub, so a burst of Pub calls lands in the subscription's own unbounded
+// queue instead of racing the feeder goroutine for an unbuffered send.
+const subscribeBridgeCapacity = 64
+
+// subscribeBridgeBlockTimeout bounds how long a Pub broadcasting into a
+// full bridge channel blocks waiting for feed() to drain it. feed() does
+// nothing but pull from the bridge and append to the unbounded queue, so
+// under ordinary bursts this lets the queue absorb the backlog instead of
+// PolicyDropNewest silently discarding it; only if feed() is starved for
+// longer than this is a message actually dropped.
+const subscribeBridgeBlockTimeout = time.Second
+
+// Subscribe registers cb to be called, in order, for every message
+// broadcast via Pub. cb runs on its own goroutine, decoupled from the Hub's
+// Start loop and from every other subscriber.
+func (h *Hub) Subscribe(cb func(msg interface{})) *Subscription {
+	ch := h.SubWithOptions(SubOptions{
+		Capacity: subscribeBridgeCapacity,
+		Policy:   PolicyBlockWithTimeout(subscribeBridgeBlockTimeout),
+	})
+
+	return newSubscription(h, ch, cb)
+}
+
+// SubscribeWithContext is Subscribe, but stops delivering (dropping and
+// Unrefing any messages still queued) and unsubscribes once ctx is done.
+func (h *Hub) SubscribeWithContext(ctx context.Context, cb func(msg interface{})) *Subscription {
+	s := h.Subscribe(cb)
+
+	go func() {
+		<-ctx.Done()
+
+		s.unsubscribe()
+	}()
+
+	return s
+}
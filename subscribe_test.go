@@ -0,0 +1,171 @@
+package hub
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/goleak"
+)
+
+func TestSubscribeFIFO(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	hub.Start(ctx)
+	defer hub.Stop()
+
+	var mu sync.Mutex
+	var got []int
+
+	done := make(chan struct{})
+
+	sub := hub.Subscribe(func(msg interface{}) {
+		mu.Lock()
+		got = append(got, msg.(int))
+		n := len(got)
+		mu.Unlock()
+
+		if n == 5 {
+			close(done)
+		}
+	})
+
+	for i := 0; i < 5; i++ {
+		hub.Pub(i)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatal("not all messages delivered in time")
+	}
+
+	mu.Lock()
+	for i, v := range got {
+		if v != i {
+			t.Errorf("got[%d] = %d, want %d (FIFO violated)", i, v, i)
+		}
+	}
+	mu.Unlock()
+
+	sub.Unsubscribe()
+}
+
+func TestSubscribeUnsubscribeNoLeak(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	hub.Start(ctx)
+
+	subs := make([]*Subscription, 10)
+	for i := range subs {
+		subs[i] = hub.Subscribe(func(msg interface{}) {})
+	}
+
+	for _, sub := range subs {
+		sub.Unsubscribe()
+	}
+
+	hub.Stop()
+	<-hub.Done()
+}
+
+func TestSubscribeUnsubscribeAfterHubStop(t *testing.T) {
+	defer goleak.VerifyNone(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	hub.Start(ctx)
+
+	sub := hub.Subscribe(func(msg interface{}) {})
+
+	hub.Stop()
+	<-hub.Done()
+
+	done := make(chan struct{})
+	go func() {
+		sub.Unsubscribe()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		t.Fatal("Unsubscribe hung after the Hub's run loop had already stopped")
+	}
+}
+
+func TestSubscribeWithContextDropsOnExpiry(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	hub.Start(ctx)
+	defer hub.Stop()
+
+	subCtx, subCancel := context.WithCancel(context.Background())
+	defer subCancel()
+
+	blockCb := make(chan struct{})
+	var calls int
+	var mu sync.Mutex
+
+	sub := hub.SubscribeWithContext(subCtx, func(msg interface{}) {
+		<-blockCb
+
+		mu.Lock()
+		calls++
+		mu.Unlock()
+	})
+
+	// first message is held in the callback, the rest pile up in the queue
+	for i := 0; i < 5; i++ {
+		hub.Pub(i)
+	}
+
+	subCancel()
+
+	// subCancel's drop goroutine races the delivery loop: without waiting
+	// for it to flip sub.drop first, the delivery loop can drain all five
+	// queued messages before the drop takes effect, making the assertion
+	// below flaky. Poll the unexported flag (same package) so the first
+	// callback only unblocks once every later pop is guaranteed to see it.
+	deadline := time.Now().Add(timeout)
+	for {
+		sub.mu.Lock()
+		drop := sub.drop
+		sub.mu.Unlock()
+
+		if drop {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("context cancellation never set sub.drop")
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	// allow the blocked callback to return so the delivery goroutine can
+	// observe the drop and exit
+	close(blockCb)
+
+	<-sub.delivery
+
+	mu.Lock()
+	n := calls
+	mu.Unlock()
+
+	if n >= 5 {
+		t.Errorf("expected some queued messages to be dropped after context expiry, got %d calls", n)
+	}
+}
@@ -0,0 +1,111 @@
+package hub
+
+import (
+	"context"
+	"strings"
+)
+
+// topicRegistration is sent over Hub.subTopic to register sub under one or
+// more topic patterns.
+type topicRegistration struct {
+	ch     chan interface{}
+	topics []string
+}
+
+// topicMsg is sent over Hub.pubTopic to publish msg on topic.
+type topicMsg struct {
+	topic string
+	msg   interface{}
+}
+
+// topicMatch reports whether topic matches pattern, where pattern may use
+// "*" to match exactly one dot-separated token and a trailing ">" to match
+// one or more trailing tokens (NATS-style subject wildcards). ">" is only
+// honored in the final position; elsewhere it is matched literally, so e.g.
+// "a.>.b" never matches anything but the (unlikely) literal topic "a.>.b".
+func topicMatch(pattern, topic string) bool {
+	pTokens := strings.Split(pattern, ".")
+	tTokens := strings.Split(topic, ".")
+
+	for i, pt := range pTokens {
+		if pt == ">" && i == len(pTokens)-1 {
+			return i < len(tTokens)
+		}
+
+		if i >= len(tTokens) {
+			return false
+		}
+
+		if pt == "*" {
+			continue
+		}
+
+		if pt != tTokens[i] {
+			return false
+		}
+	}
+
+	return len(pTokens) == len(tTokens)
+}
+
+// SubTopic subscribes to a single topic pattern, e.g. "foo.bar", "foo.*" or
+// "foo.>". Messages are delivered only via PubTopic/PubTopicWithContext,
+// independent of the plain Sub/Pub broadcast.
+func (h *Hub) SubTopic(topic string, capacity int) (sub chan interface{}) {
+	return h.SubMulti([]string{topic}, capacity)
+}
+
+// SubMulti subscribes to several topic patterns at once, delivering to the
+// same channel regardless of which pattern matched.
+func (h *Hub) SubMulti(topics []string, capacity int) (sub chan interface{}) {
+	if capacity <= 0 {
+		sub = make(chan interface{})
+	} else {
+		sub = make(chan interface{}, capacity)
+	}
+
+	h.subTopic <- topicRegistration{ch: sub, topics: topics}
+
+	return sub
+}
+
+// SubMultiWithContext is SubMulti but gives up registering if ctx is done
+// first.
+func (h *Hub) SubMultiWithContext(ctx context.Context, topics []string, capacity int) (sub chan interface{}) {
+	if capacity <= 0 {
+		sub = make(chan interface{})
+	} else {
+		sub = make(chan interface{}, capacity)
+	}
+
+	select {
+	case h.subTopic <- topicRegistration{ch: sub, topics: topics}:
+	case <-ctx.Done():
+	}
+
+	return sub
+}
+
+// PubTopic publishes msg on topic to every subscription whose pattern
+// matches it.
+func (h *Hub) PubTopic(topic string, msg interface{}) {
+	h.pubTopic <- topicMsg{topic: topic, msg: msg}
+}
+
+// PubTopicWithContext is PubTopic but gives up publishing if ctx is done
+// first.
+func (h *Hub) PubTopicWithContext(ctx context.Context, topic string, msg interface{}) {
+	select {
+	case h.pubTopic <- topicMsg{topic: topic, msg: msg}:
+	case <-ctx.Done():
+	}
+}
+
+// Topics returns the topic patterns currently registered by at least one
+// subscriber, sorted lexically.
+func (h *Hub) Topics() []string {
+	resp := make(chan []string, 1)
+	h.topicsReq <- resp
+
+	return <-resp
+}
@@ -0,0 +1,75 @@
+package hub
+
+import (
+	"context"
+	"sort"
+	"testing"
+)
+
+func TestTopicMatch(t *testing.T) {
+	tests := []struct {
+		pattern string
+		topic   string
+		want    bool
+	}{
+		{"foo.bar", "foo.bar", true},
+		{"foo.bar", "foo.baz", false},
+		{"foo.*", "foo.bar", true},
+		{"foo.*", "foo.bar.baz", false},
+		{"foo.>", "foo.bar", true},
+		{"foo.>", "foo.bar.baz", true},
+		{"foo.>", "foo", false},
+		{"*.bar", "foo.bar", true},
+		{"*.bar", "foo.baz", false},
+		{"a.>.b", "a.x.b", false},
+		{"a.>.b", "a.>.b", true},
+	}
+
+	for _, tt := range tests {
+		if got := topicMatch(tt.pattern, tt.topic); got != tt.want {
+			t.Errorf("topicMatch(%q, %q) = %v, want %v", tt.pattern, tt.topic, got, tt.want)
+		}
+	}
+}
+
+func TestHubTopics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	hub := NewHub()
+	hub.Start(ctx)
+	defer hub.Stop()
+
+	subFooBar := hub.SubTopic("foo.bar", 1)
+	subFooStar := hub.SubTopic("foo.*", 1)
+	subMulti := hub.SubMulti([]string{"foo.bar", "baz.>"}, 1)
+
+	hub.PubTopic("foo.bar", "hello")
+
+	if msg := <-subFooBar; msg != "hello" {
+		t.Errorf("subFooBar got %v, want %q", msg, "hello")
+	}
+
+	if msg := <-subFooStar; msg != "hello" {
+		t.Errorf("subFooStar got %v, want %q", msg, "hello")
+	}
+
+	if msg := <-subMulti; msg != "hello" {
+		t.Errorf("subMulti got %v, want %q", msg, "hello")
+	}
+
+	topics := hub.Topics()
+	sort.Strings(topics)
+
+	want := []string{"baz.>", "foo.*", "foo.bar"}
+
+	if len(topics) != len(want) {
+		t.Fatalf("Topics() = %v, want %v", topics, want)
+	}
+
+	for i := range want {
+		if topics[i] != want[i] {
+			t.Errorf("Topics() = %v, want %v", topics, want)
+		}
+	}
+}